@@ -0,0 +1,171 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	commonconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Config is the top-level configuration for Prometheus's config file.
+//
+// This checkout only carries the subset consumed by the tracing package;
+// the rest of Prometheus's configuration (global, scrape_configs,
+// remote_write, ...) lives alongside this in the full repository.
+type Config struct {
+	TracingConfig TracingConfig `yaml:"tracing,omitempty"`
+}
+
+// TracingClientType represents the protocol used to send OTLP spans.
+type TracingClientType string
+
+// Supported OTLP client types.
+const (
+	TracingClientGRPC TracingClientType = "grpc"
+	TracingClientHTTP TracingClientType = "http"
+)
+
+// TracingExporterType selects which tracesdk.SpanExporter backend the
+// tracing Manager builds.
+type TracingExporterType string
+
+// Supported exporter backends.
+const (
+	TracingExporterOTLP   TracingExporterType = "otlp"
+	TracingExporterJaeger TracingExporterType = "jaeger"
+)
+
+// SamplerType selects the sampling strategy installed on the tracer
+// provider.
+type SamplerType string
+
+// Supported sampler types.
+const (
+	SamplerAlwaysOn                SamplerType = "always_on"
+	SamplerAlwaysOff               SamplerType = "always_off"
+	SamplerParentBasedTraceIDRatio SamplerType = "parentbased_traceidratio"
+	SamplerParentBasedAlwaysOn     SamplerType = "parentbased_always_on"
+	SamplerJaegerRemote            SamplerType = "jaeger_remote"
+)
+
+// TracingConfig configures the tracing.Manager: which exporter to build,
+// how to reach it, and how to sample.
+type TracingConfig struct {
+	// ClientType selects the OTLP protocol (grpc or http). Only consulted
+	// when ExporterType is otlp (or unset).
+	ClientType TracingClientType `yaml:"client_type,omitempty"`
+	// ExporterType selects the exporter backend. Defaults to otlp.
+	ExporterType TracingExporterType `yaml:"exporter_type,omitempty"`
+	// Endpoint is the OTLP collector address shared by both signals,
+	// unless overridden per-signal by GRPCEndpoint/HTTPEndpoint.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// GRPCEndpoint overrides Endpoint for the gRPC client.
+	GRPCEndpoint string `yaml:"grpc_endpoint,omitempty"`
+	// HTTPEndpoint overrides Endpoint for the HTTP client.
+	HTTPEndpoint string `yaml:"http_endpoint,omitempty"`
+	// URLPath is appended to the endpoint used by the HTTP client (ignored by gRPC).
+	URLPath string `yaml:"url_path,omitempty"`
+	// WithSecure enables TLS on the OTLP client connection.
+	WithSecure bool `yaml:"with_secure,omitempty"`
+	// TLSConfig configures the TLS connection used when WithSecure is set.
+	TLSConfig commonconfig.TLSConfig `yaml:"tls_config,omitempty"`
+	// Headers are sent with every export request (e.g. a tenant or auth token).
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Compression selects the wire compression algorithm ("gzip" or empty).
+	Compression string `yaml:"compression,omitempty"`
+	// Timeout bounds each export request.
+	Timeout model.Duration `yaml:"timeout,omitempty"`
+	// HTTPClientConfig carries OAuth2/basic-auth/bearer-token settings,
+	// applied only when the HTTP client is in use.
+	HTTPClientConfig commonconfig.HTTPClientConfig `yaml:"http_config,omitempty"`
+	// ServiceName identifies this Prometheus instance in the exported resource.
+	ServiceName string `yaml:"service_name,omitempty"`
+	// SamplingFraction is the ratio used by the default
+	// parentbased_traceidratio sampler.
+	SamplingFraction float64 `yaml:"sampling_fraction,omitempty"`
+	// Sampler configures which sampling strategy to install.
+	Sampler SamplerConfig `yaml:"sampler,omitempty"`
+	// Jaeger configures the Jaeger exporter, used when ExporterType is jaeger.
+	Jaeger JaegerConfig `yaml:"jaeger,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TracingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TracingConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that the exporter and sampler types are ones
+// tracing.Manager actually knows how to build, so a typo surfaces at
+// config-load time rather than as a runtime error from ApplyConfig.
+func (c *TracingConfig) Validate() error {
+	switch c.ExporterType {
+	case "", TracingExporterOTLP, TracingExporterJaeger:
+	default:
+		return fmt.Errorf("unknown tracing exporter type %q", c.ExporterType)
+	}
+
+	switch c.ClientType {
+	case "", TracingClientGRPC, TracingClientHTTP:
+	default:
+		return fmt.Errorf("unknown tracing client type %q", c.ClientType)
+	}
+
+	return c.Sampler.Validate()
+}
+
+// JaegerConfig configures the Jaeger exporter. Either AgentHost (agent/UDP
+// mode) or CollectorEndpoint (direct collector push) should be set.
+type JaegerConfig struct {
+	AgentHost         string `yaml:"agent_host,omitempty"`
+	AgentPort         string `yaml:"agent_port,omitempty"`
+	CollectorEndpoint string `yaml:"collector_endpoint,omitempty"`
+	User              string `yaml:"user,omitempty"`
+	Password          string `yaml:"password,omitempty"`
+}
+
+// SamplerConfig configures the sampling strategy and an optional rate cap
+// applied on top of it.
+type SamplerConfig struct {
+	Type SamplerType `yaml:"type,omitempty"`
+	// MaxSpansPerSecond, when > 0, wraps Type's sampler in a rate limiter
+	// that caps the number of spans recorded per second.
+	MaxSpansPerSecond float64 `yaml:"max_spans_per_second,omitempty"`
+	// JaegerRemote configures the jaeger_remote sampler. Only consulted
+	// when Type is jaeger_remote.
+	JaegerRemote JaegerRemoteSamplerConfig `yaml:"jaeger_remote,omitempty"`
+}
+
+// JaegerRemoteSamplerConfig configures polling of a Jaeger
+// sampling-strategy endpoint for the jaeger_remote sampler.
+type JaegerRemoteSamplerConfig struct {
+	Endpoint        string         `yaml:"endpoint,omitempty"`
+	PollingInterval model.Duration `yaml:"polling_interval,omitempty"`
+}
+
+// Validate checks that Type is one buildSampler actually knows how to
+// build.
+func (c *SamplerConfig) Validate() error {
+	switch c.Type {
+	case "", SamplerAlwaysOn, SamplerAlwaysOff, SamplerParentBasedTraceIDRatio, SamplerParentBasedAlwaysOn, SamplerJaegerRemote:
+		return nil
+	default:
+		return fmt.Errorf("unknown tracing sampler type %q", c.Type)
+	}
+}
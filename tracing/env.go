@@ -0,0 +1,160 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// OTEL SDK environment variables honored by the tracing Manager, as defined
+// by https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/configuration/sdk-environment-variables.md.
+const (
+	envOTLPEndpoint     = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envOTLPProtocol     = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envOTLPHeaders      = "OTEL_EXPORTER_OTLP_HEADERS"
+	envOTLPTimeout      = "OTEL_EXPORTER_OTLP_TIMEOUT"
+	envOTLPCompression  = "OTEL_EXPORTER_OTLP_COMPRESSION"
+	envTracesSampler    = "OTEL_TRACES_SAMPLER"
+	envTracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
+)
+
+// tracingEnabled reports whether an exporter endpoint was configured, either
+// via prometheus.yml or one of the standard OTEL environment variables. When
+// it isn't, the Manager installs a no-op tracer provider instead of
+// constantly retrying a connection to a default endpoint.
+func tracingEnabled(tracingCfg config.TracingConfig) bool {
+	if tracingCfg.ExporterType == config.TracingExporterJaeger {
+		return tracingCfg.Jaeger.AgentHost != "" || tracingCfg.Jaeger.CollectorEndpoint != ""
+	}
+
+	if tracingCfg.Endpoint != "" || tracingCfg.GRPCEndpoint != "" || tracingCfg.HTTPEndpoint != "" {
+		return true
+	}
+
+	return os.Getenv(envOTLPEndpoint) != ""
+}
+
+// otlpEndpoint resolves the OTLP endpoint for the given client type from
+// config, falling back to the standard environment variable. A per-signal
+// endpoint (GRPCEndpoint/HTTPEndpoint) takes precedence over the shared
+// Endpoint field, which in turn takes precedence over the environment.
+func otlpEndpoint(tracingCfg config.TracingConfig, clientType config.TracingClientType) string {
+	if clientType == config.TracingClientHTTP && tracingCfg.HTTPEndpoint != "" {
+		return tracingCfg.HTTPEndpoint
+	}
+	if clientType == config.TracingClientGRPC && tracingCfg.GRPCEndpoint != "" {
+		return tracingCfg.GRPCEndpoint
+	}
+	if tracingCfg.Endpoint != "" {
+		return tracingCfg.Endpoint
+	}
+	return os.Getenv(envOTLPEndpoint)
+}
+
+// otlpClientType resolves the OTLP client type (gRPC or HTTP) from config,
+// falling back to OTEL_EXPORTER_OTLP_PROTOCOL when unset.
+func otlpClientType(tracingCfg config.TracingConfig) config.TracingClientType {
+	if tracingCfg.ClientType != "" {
+		return tracingCfg.ClientType
+	}
+
+	switch os.Getenv(envOTLPProtocol) {
+	case "http", "http/protobuf":
+		return config.TracingClientHTTP
+	default:
+		return config.TracingClientGRPC
+	}
+}
+
+// otlpHeaders resolves additional headers to send with each export request,
+// preferring the configured headers and falling back to parsing
+// OTEL_EXPORTER_OTLP_HEADERS (a comma-separated list of key=value pairs).
+func otlpHeaders(tracingCfg config.TracingConfig) map[string]string {
+	if len(tracingCfg.Headers) > 0 {
+		return tracingCfg.Headers
+	}
+
+	raw := os.Getenv(envOTLPHeaders)
+	if raw == "" {
+		return nil
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// otlpTimeout resolves the export timeout from config, falling back to
+// OTEL_EXPORTER_OTLP_TIMEOUT (milliseconds, per the OTEL spec).
+func otlpTimeout(tracingCfg config.TracingConfig) time.Duration {
+	if tracingCfg.Timeout != 0 {
+		return time.Duration(tracingCfg.Timeout)
+	}
+
+	ms := os.Getenv(envOTLPTimeout)
+	if ms == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(ms + "ms")
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// otlpCompression resolves the compression algorithm from config, falling
+// back to OTEL_EXPORTER_OTLP_COMPRESSION.
+func otlpCompression(tracingCfg config.TracingConfig) string {
+	if tracingCfg.Compression != "" {
+		return tracingCfg.Compression
+	}
+	return os.Getenv(envOTLPCompression)
+}
+
+// samplerFromEnvOrConfig builds the sampler to install on the tracer
+// provider, preferring OTEL_TRACES_SAMPLER/OTEL_TRACES_SAMPLER_ARG over the
+// configured sampling fraction when the former is set.
+func samplerFromEnvOrConfig(tracingCfg config.TracingConfig) tracesdk.Sampler {
+	ratio := tracingCfg.SamplingFraction
+
+	switch os.Getenv(envTracesSampler) {
+	case "always_on":
+		return tracesdk.AlwaysSample()
+	case "always_off":
+		return tracesdk.NeverSample()
+	case "parentbased_always_on":
+		return tracesdk.ParentBased(tracesdk.AlwaysSample())
+	case "parentbased_always_off":
+		return tracesdk.ParentBased(tracesdk.NeverSample())
+	case "traceidratio", "parentbased_traceidratio":
+		if arg, err := strconv.ParseFloat(os.Getenv(envTracesSamplerArg), 64); err == nil {
+			ratio = arg
+		}
+	}
+
+	return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio))
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+func TestTracingEnabled(t *testing.T) {
+	t.Run("disabled when nothing is configured", func(t *testing.T) {
+		require.False(t, tracingEnabled(config.TracingConfig{}))
+	})
+
+	t.Run("enabled by the shared endpoint", func(t *testing.T) {
+		require.True(t, tracingEnabled(config.TracingConfig{Endpoint: "localhost:4317"}))
+	})
+
+	t.Run("enabled by a per-signal endpoint alone", func(t *testing.T) {
+		require.True(t, tracingEnabled(config.TracingConfig{GRPCEndpoint: "localhost:4317"}))
+		require.True(t, tracingEnabled(config.TracingConfig{HTTPEndpoint: "localhost:4318"}))
+	})
+
+	t.Run("enabled by the environment", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+		require.True(t, tracingEnabled(config.TracingConfig{}))
+	})
+
+	t.Run("jaeger exporter is only enabled by its own fields", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+		cfg := config.TracingConfig{ExporterType: config.TracingExporterJaeger}
+		require.False(t, tracingEnabled(cfg), "the OTLP endpoint env var shouldn't enable the jaeger exporter")
+
+		cfg.Jaeger.AgentHost = "localhost"
+		require.True(t, tracingEnabled(cfg))
+	})
+}
+
+func TestOtlpEndpoint(t *testing.T) {
+	t.Run("per-signal endpoint wins over the shared endpoint", func(t *testing.T) {
+		cfg := config.TracingConfig{Endpoint: "shared:4317", GRPCEndpoint: "grpc-only:4317"}
+		require.Equal(t, "grpc-only:4317", otlpEndpoint(cfg, config.TracingClientGRPC))
+		require.Equal(t, "shared:4317", otlpEndpoint(cfg, config.TracingClientHTTP))
+	})
+
+	t.Run("shared endpoint wins over the environment", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "env:4317")
+		cfg := config.TracingConfig{Endpoint: "shared:4317"}
+		require.Equal(t, "shared:4317", otlpEndpoint(cfg, config.TracingClientGRPC))
+	})
+
+	t.Run("falls back to the environment when nothing is configured", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "env:4317")
+		require.Equal(t, "env:4317", otlpEndpoint(config.TracingConfig{}, config.TracingClientGRPC))
+	})
+}
+
+func TestOtlpClientType(t *testing.T) {
+	t.Run("config wins over the environment", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http")
+		cfg := config.TracingConfig{ClientType: config.TracingClientGRPC}
+		require.Equal(t, config.TracingClientGRPC, otlpClientType(cfg))
+	})
+
+	t.Run("falls back to OTEL_EXPORTER_OTLP_PROTOCOL", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+		require.Equal(t, config.TracingClientHTTP, otlpClientType(config.TracingConfig{}))
+	})
+
+	t.Run("defaults to gRPC when unset", func(t *testing.T) {
+		require.Equal(t, config.TracingClientGRPC, otlpClientType(config.TracingConfig{}))
+	})
+}
+
+func TestOtlpHeaders(t *testing.T) {
+	t.Run("configured headers win over the environment", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-env=1")
+		cfg := config.TracingConfig{Headers: map[string]string{"x-config": "1"}}
+		require.Equal(t, map[string]string{"x-config": "1"}, otlpHeaders(cfg))
+	})
+
+	t.Run("parses comma-separated key=value pairs from the environment", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-tenant=foo,x-token= bar ")
+		require.Equal(t, map[string]string{"x-tenant": "foo", "x-token": "bar"}, otlpHeaders(config.TracingConfig{}))
+	})
+
+	t.Run("nil when neither is set", func(t *testing.T) {
+		require.Nil(t, otlpHeaders(config.TracingConfig{}))
+	})
+}
+
+func TestSamplerFromEnvOrConfig(t *testing.T) {
+	t.Run("OTEL_TRACES_SAMPLER overrides the configured fraction", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_SAMPLER", "always_on")
+		sampler := samplerFromEnvOrConfig(config.TracingConfig{SamplingFraction: 0})
+		require.Contains(t, sampler.Description(), "AlwaysOnSampler")
+	})
+
+	t.Run("traceidratio sampler arg overrides the configured fraction", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+		t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.5")
+		sampler := samplerFromEnvOrConfig(config.TracingConfig{SamplingFraction: 0.1})
+		require.Contains(t, sampler.Description(), "0.5")
+	})
+
+	t.Run("falls back to the configured fraction when unset", func(t *testing.T) {
+		sampler := samplerFromEnvOrConfig(config.TracingConfig{SamplingFraction: 0.25})
+		require.Contains(t, sampler.Description(), "0.25")
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// buildExporter constructs the tracesdk.SpanExporter requested by the
+// tracing configuration. Adding a new backend (Zipkin, stdout, ...) means
+// adding a case here plus a dedicated builder function, without touching
+// the Manager or buildTracerProvider.
+func buildExporter(ctx context.Context, tracingCfg config.TracingConfig) (tracesdk.SpanExporter, error) {
+	switch tracingCfg.ExporterType {
+	case "", config.TracingExporterOTLP:
+		return buildOTLPExporter(ctx, tracingCfg)
+	case config.TracingExporterJaeger:
+		return buildJaegerExporter(tracingCfg)
+	default:
+		return nil, errors.Errorf("unknown tracing exporter type %q", tracingCfg.ExporterType)
+	}
+}
+
+// buildOTLPExporter builds the OTLP exporter, picking a gRPC or HTTP client
+// depending on the configured client type.
+func buildOTLPExporter(ctx context.Context, tracingCfg config.TracingConfig) (tracesdk.SpanExporter, error) {
+	client, err := getClient(tracingCfg)
+	if err != nil {
+		return nil, err
+	}
+	return otlptrace.New(ctx, client)
+}
+
+// buildJaegerExporter builds a Jaeger exporter, either reporting to a local
+// Jaeger agent over UDP or pushing directly to a Jaeger collector.
+func buildJaegerExporter(tracingCfg config.TracingConfig) (tracesdk.SpanExporter, error) {
+	jaegerCfg := tracingCfg.Jaeger
+
+	if jaegerCfg.AgentHost != "" {
+		opts := []jaeger.AgentEndpointOption{jaeger.WithAgentHost(jaegerCfg.AgentHost), jaeger.WithAgentPort(jaegerCfg.AgentPort)}
+		return jaeger.New(jaeger.WithAgentEndpoint(opts...))
+	}
+
+	opts := []jaeger.CollectorEndpointOption{jaeger.WithEndpoint(jaegerCfg.CollectorEndpoint)}
+	if jaegerCfg.User != "" {
+		opts = append(opts, jaeger.WithUsername(jaegerCfg.User), jaeger.WithPassword(jaegerCfg.Password))
+	}
+
+	return jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+}
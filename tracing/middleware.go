@@ -0,0 +1,34 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HTTPMiddleware wraps next so that inbound requests carrying a traceparent
+// header (e.g. from Grafana, or from another Prometheus instance acting as
+// a remote-write client) continue the caller's trace instead of starting a
+// new one, and so each request is recorded as a server span tagged with
+// HTTP semantic conventions.
+//
+// The API server and remote-write receiver aren't part of this checkout, so
+// this isn't wired up anywhere yet; it's meant as the single place they
+// should both reach for trace-context propagation rather than each
+// importing otelhttp directly with their own options.
+func HTTPMiddleware(operation string, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, operation)
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	commonconfig "github.com/prometheus/common/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// getClient returns an appropriate OTLP client (either gRPC or HTTP), based
+// on the provided tracing configuration, falling back to the standard OTEL
+// exporter environment variables wherever a value isn't set in config. It
+// errors out rather than silently ignoring a tls_config or http_config that
+// fails to build, so a bad cert/key/CA path or auth setting fails config
+// reload loudly instead of shipping spans over an unintended connection.
+func getClient(tracingCfg config.TracingConfig) (otlptrace.Client, error) {
+	headers := otlpHeaders(tracingCfg)
+	timeout := otlpTimeout(tracingCfg)
+	compression := otlpCompression(tracingCfg)
+
+	if otlpClientType(tracingCfg) == config.TracingClientHTTP {
+		return otlptracehttpClient(tracingCfg, headers, timeout, compression)
+	}
+	return otlptracegrpcClient(tracingCfg, headers, timeout, compression)
+}
+
+func otlptracegrpcClient(tracingCfg config.TracingConfig, headers map[string]string, timeout time.Duration, compression string) (otlptrace.Client, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(otlpEndpoint(tracingCfg, config.TracingClientGRPC))}
+
+	if tracingCfg.WithSecure {
+		tlsConfig, err := commonconfig.NewTLSConfig(&tracingCfg.TLSConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to build TLS config for the OTLP gRPC exporter")
+		}
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	if len(headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(headers))
+	}
+	if timeout > 0 {
+		opts = append(opts, otlptracegrpc.WithTimeout(timeout))
+	}
+	if compression == "gzip" {
+		opts = append(opts, otlptracegrpc.WithCompressor("gzip"))
+	}
+
+	return otlptracegrpc.NewClient(opts...), nil
+}
+
+func otlptracehttpClient(tracingCfg config.TracingConfig, headers map[string]string, timeout time.Duration, compression string) (otlptrace.Client, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(otlpEndpoint(tracingCfg, config.TracingClientHTTP))}
+
+	if tracingCfg.URLPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(tracingCfg.URLPath))
+	}
+
+	if tracingCfg.WithSecure {
+		tlsConfig, err := commonconfig.NewTLSConfig(&tracingCfg.TLSConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to build TLS config for the OTLP HTTP exporter")
+		}
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	} else {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	httpClient, err := httpClientFromConfig(tracingCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build HTTP client for the OTLP HTTP exporter")
+	}
+	if httpClient != nil {
+		// WithHTTPClient only preempts WithProxy/WithTimeout/WithTLSClientConfig;
+		// headers are applied per-request in newRequest regardless of which
+		// http.Client is in use, so the WithHeaders option below must stay unconditional on httpClient
+		// being set, or configuring both headers (e.g. a tenant ID) and
+		// http_config (e.g. OAuth2) would silently drop the former.
+		opts = append(opts, otlptracehttp.WithHTTPClient(httpClient))
+	}
+	if len(headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(headers))
+	}
+
+	if timeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(timeout))
+	}
+	if compression == "gzip" {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+
+	return otlptracehttp.NewClient(opts...), nil
+}
+
+// httpClientFromConfig builds an *http.Client from the tracing config's
+// HTTPClientConfig, so operators can reuse Prometheus's standard OAuth2,
+// basic auth and bearer token mechanisms for the HTTP exporter. It returns
+// a nil client (and no error) when no such auth is configured, so the
+// caller falls back to plain headers.
+func httpClientFromConfig(tracingCfg config.TracingConfig) (*http.Client, error) {
+	if reflect.DeepEqual(tracingCfg.HTTPClientConfig, commonconfig.HTTPClientConfig{}) {
+		return nil, nil
+	}
+
+	return commonconfig.NewClientFromConfig(tracingCfg.HTTPClientConfig, "tracing")
+}
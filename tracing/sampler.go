@@ -0,0 +1,201 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/time/rate"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+const (
+	defaultJaegerRemotePollingInterval = 60 * time.Second
+	jaegerRemoteFetchTimeout           = 5 * time.Second
+)
+
+// buildSampler constructs the sampler requested by the tracing
+// configuration, wrapping it in a rate limiter when MaxSpansPerSecond is
+// set. It returns a stop function that must be called on shutdown to tear
+// down any background work (currently only the jaeger_remote sampler's
+// polling loop).
+func buildSampler(logger log.Logger, tracingCfg config.TracingConfig) (tracesdk.Sampler, func(), error) {
+	var (
+		sampler tracesdk.Sampler
+		stop    = func() {}
+	)
+
+	switch tracingCfg.Sampler.Type {
+	case "", config.SamplerParentBasedTraceIDRatio:
+		sampler = samplerFromEnvOrConfig(tracingCfg)
+	case config.SamplerAlwaysOn:
+		sampler = tracesdk.AlwaysSample()
+	case config.SamplerAlwaysOff:
+		sampler = tracesdk.NeverSample()
+	case config.SamplerParentBasedAlwaysOn:
+		sampler = tracesdk.ParentBased(tracesdk.AlwaysSample())
+	case config.SamplerJaegerRemote:
+		sampler, stop = newJaegerRemoteSampler(logger, tracingCfg)
+	default:
+		return nil, nil, errors.Errorf("unknown tracing sampler type %q", tracingCfg.Sampler.Type)
+	}
+
+	if tracingCfg.Sampler.MaxSpansPerSecond > 0 {
+		sampler = newRateLimitedSampler(sampler, tracingCfg.Sampler.MaxSpansPerSecond)
+	}
+
+	return sampler, stop, nil
+}
+
+// rateLimitedSampler wraps another sampler and caps the number of spans it
+// lets through to RecordAndSample per second, protecting the collector from
+// a burst of chatty parents even when the wrapped sampler would otherwise
+// sample them.
+type rateLimitedSampler struct {
+	wrapped tracesdk.Sampler
+	limiter *rate.Limiter
+}
+
+func newRateLimitedSampler(wrapped tracesdk.Sampler, maxSpansPerSecond float64) tracesdk.Sampler {
+	return &rateLimitedSampler{
+		wrapped: wrapped,
+		limiter: rate.NewLimiter(rate.Limit(maxSpansPerSecond), int(maxSpansPerSecond)+1),
+	}
+}
+
+func (s *rateLimitedSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	result := s.wrapped.ShouldSample(p)
+	if result.Decision != tracesdk.Drop && !s.limiter.Allow() {
+		result.Decision = tracesdk.Drop
+	}
+	return result
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return "RateLimited{" + s.wrapped.Description() + "}"
+}
+
+// jaegerRemoteSampler periodically polls a Jaeger sampling-strategy
+// endpoint (the same API `jaeger-agent`/`jaeger-collector` serve) for the
+// service's probabilistic sampling rate, and delegates ShouldSample to a ratio
+// sampler built from whatever it last fetched. Per-operation strategies in the
+// response are not read; every span uses the service-level rate. It falls back to the configured
+// SamplingFraction until the first successful poll, and keeps serving the
+// last known-good probability if a later poll fails.
+type jaegerRemoteSampler struct {
+	current atomic.Value // tracesdk.Sampler
+}
+
+func newJaegerRemoteSampler(logger log.Logger, tracingCfg config.TracingConfig) (*jaegerRemoteSampler, func()) {
+	s := &jaegerRemoteSampler{}
+	s.current.Store(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(tracingCfg.SamplingFraction)))
+
+	interval := time.Duration(tracingCfg.Sampler.JaegerRemote.PollingInterval)
+	if interval <= 0 {
+		interval = defaultJaegerRemotePollingInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.run(ctx, logger, tracingCfg, interval)
+
+	return s, cancel
+}
+
+func (s *jaegerRemoteSampler) run(ctx context.Context, logger log.Logger, tracingCfg config.TracingConfig, interval time.Duration) {
+	s.refresh(ctx, logger, tracingCfg)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx, logger, tracingCfg)
+		}
+	}
+}
+
+func (s *jaegerRemoteSampler) refresh(ctx context.Context, logger log.Logger, tracingCfg config.TracingConfig) {
+	fetchCtx, cancel := context.WithTimeout(ctx, jaegerRemoteFetchTimeout)
+	defer cancel()
+
+	ratio, err := fetchJaegerSamplingRate(fetchCtx, tracingCfg.Sampler.JaegerRemote.Endpoint, tracingCfg.ServiceName)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to refresh jaeger_remote sampling strategy, keeping last known value", "err", err)
+		return
+	}
+
+	s.current.Store(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio)))
+}
+
+func (s *jaegerRemoteSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return s.current.Load().(tracesdk.Sampler).ShouldSample(p)
+}
+
+func (s *jaegerRemoteSampler) Description() string {
+	return "JaegerRemoteSampler"
+}
+
+// jaegerSamplingStrategy is the subset of Jaeger's sampling strategy
+// response (https://www.jaegertracing.io/docs/1.6/sampling/#collector-sampling-configuration)
+// this package understands: per-service probabilistic sampling.
+type jaegerSamplingStrategy struct {
+	ProbabilisticSampling struct {
+		SamplingRate float64 `json:"samplingRate"`
+	} `json:"probabilisticSampling"`
+}
+
+// jaegerRemoteHTTPClient bounds each poll with its own timeout on top of
+// the context deadline passed to fetchJaegerSamplingRate, so a dead
+// sampling-strategy endpoint can't wedge the poller or leak the fetch past
+// shutdown.
+var jaegerRemoteHTTPClient = &http.Client{Timeout: jaegerRemoteFetchTimeout}
+
+func fetchJaegerSamplingRate(ctx context.Context, endpoint, service string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	q := req.URL.Query()
+	q.Set("service", service)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := jaegerRemoteHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("unexpected status %d fetching jaeger sampling strategy", resp.StatusCode)
+	}
+
+	var strategy jaegerSamplingStrategy
+	if err := json.NewDecoder(resp.Body).Decode(&strategy); err != nil {
+		return 0, err
+	}
+
+	return strategy.ProbabilisticSampling.SamplingRate, nil
+}
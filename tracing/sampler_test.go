@@ -0,0 +1,125 @@
+// Copyright 2021 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// unsampledParentContext returns a context carrying a remote parent span
+// whose sampled flag is unset, the case a ParentBased sampler must respect
+// by deferring to the parent instead of always recording.
+func unsampledParentContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.TraceFlags(0),
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
+
+func testTracingConfigWithJaegerRemote(endpoint string) config.TracingConfig {
+	return config.TracingConfig{
+		ServiceName: "test",
+		Sampler: config.SamplerConfig{
+			Type:         config.SamplerJaegerRemote,
+			JaegerRemote: config.JaegerRemoteSamplerConfig{Endpoint: endpoint},
+		},
+	}
+}
+
+func TestRateLimitedSampler(t *testing.T) {
+	sampler := newRateLimitedSampler(tracesdk.ParentBased(tracesdk.AlwaysSample()), 1)
+
+	params := tracesdk.SamplingParameters{ParentContext: context.Background()}
+
+	first := sampler.ShouldSample(params)
+	require.Equal(t, tracesdk.RecordAndSample, first.Decision)
+
+	// The burst (maxSpansPerSecond + 1) is exhausted by the first sample
+	// plus whatever the limiter started with; keep asking until we
+	// observe a drop, which must happen well before spamming thousands
+	// of requests if the cap is actually enforced.
+	dropped := false
+	for i := 0; i < 10; i++ {
+		if sampler.ShouldSample(params).Decision == tracesdk.Drop {
+			dropped = true
+			break
+		}
+	}
+	require.True(t, dropped, "expected the rate limiter to eventually drop a span")
+}
+
+func TestRateLimitedSamplerNeverOverridesAnExplicitDrop(t *testing.T) {
+	sampler := newRateLimitedSampler(tracesdk.ParentBased(tracesdk.NeverSample()), 1000)
+	result := sampler.ShouldSample(tracesdk.SamplingParameters{ParentContext: context.Background()})
+	require.Equal(t, tracesdk.Drop, result.Decision)
+}
+
+func TestAlwaysOnIsNotParentBased(t *testing.T) {
+	logger := log.NewNopLogger()
+	parentCtx := unsampledParentContext()
+
+	alwaysOn, stop, err := buildSampler(logger, config.TracingConfig{Sampler: config.SamplerConfig{Type: config.SamplerAlwaysOn}})
+	require.NoError(t, err)
+	defer stop()
+
+	result := alwaysOn.ShouldSample(tracesdk.SamplingParameters{ParentContext: parentCtx})
+	require.Equal(t, tracesdk.RecordAndSample, result.Decision,
+		"always_on must record regardless of the parent's sampled flag")
+
+	parentBasedOn, stop, err := buildSampler(logger, config.TracingConfig{Sampler: config.SamplerConfig{Type: config.SamplerParentBasedAlwaysOn}})
+	require.NoError(t, err)
+	defer stop()
+
+	result = parentBasedOn.ShouldSample(tracesdk.SamplingParameters{ParentContext: parentCtx})
+	require.Equal(t, tracesdk.Drop, result.Decision,
+		"parentbased_always_on must defer to an unsampled parent instead of always recording")
+}
+
+func TestJaegerRemoteSamplerKeepsLastKnownValueOnFailedPoll(t *testing.T) {
+	var serveGoodStrategy = true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !serveGoodStrategy {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"probabilisticSampling":{"samplingRate":0.75}}`))
+	}))
+	defer srv.Close()
+
+	s := &jaegerRemoteSampler{}
+	s.current.Store(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(0.1)))
+
+	logger := log.NewNopLogger()
+
+	s.refresh(context.Background(), logger, testTracingConfigWithJaegerRemote(srv.URL))
+	require.Contains(t, s.current.Load().(tracesdk.Sampler).Description(), "0.75")
+
+	serveGoodStrategy = false
+	s.refresh(context.Background(), logger, testTracingConfigWithJaegerRemote(srv.URL))
+	require.Contains(t, s.current.Load().(tracesdk.Sampler).Description(), "0.75",
+		"a failed poll must keep serving the last known-good sampling rate")
+}
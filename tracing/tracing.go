@@ -15,16 +15,16 @@ package tracing
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/version"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelbridge "go.opentelemetry.io/otel/bridge/opentracing"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
@@ -37,16 +37,24 @@ import (
 // Manager is capable of building, (re)installing and shutting down
 // the tracer provider.
 type Manager struct {
-	logger       log.Logger
-	config       config.TracingConfig
-	shutdownFunc func() error
+	logger                  log.Logger
+	config                  config.TracingConfig
+	shutdownFunc            func() error
+	enableOpenTracingBridge bool
 }
 
 // NewManager creates a new tracing manager without installing a tracer provider.
 // It registers the global text map propagator and error handler.
-func NewManager(logger log.Logger) *Manager {
+//
+// When enableOpenTracingBridge is set, each tracer provider installed by
+// ApplyConfig is also wired up as the global github.com/opentracing/opentracing-go
+// tracer, via the OTEL bridge, so dependencies that still emit OpenTracing
+// spans (gRPC middleware, storage adapters, Thanos-derived code, ...) are
+// captured by the same OTLP pipeline.
+func NewManager(logger log.Logger, enableOpenTracingBridge bool) *Manager {
 	t := &Manager{
-		logger: logger,
+		logger:                  logger,
+		enableOpenTracingBridge: enableOpenTracingBridge,
 	}
 
 	otel.SetTextMapPropagator(propagation.TraceContext{})
@@ -60,8 +68,10 @@ func NewManager(logger log.Logger) *Manager {
 // ApplyConfig takes care of refreshing the tracing configuration by shutting down
 // the current tracer provider (if any is registered) and installing a new one.
 func (m *Manager) ApplyConfig(cfg *config.Config) error {
-	// Update only if a config change is detected.
-	if m.config == cfg.TracingConfig {
+	// Update only if a config change is detected. TracingConfig carries a
+	// Headers map (added alongside GRPCEndpoint/HTTPEndpoint etc.), so it's
+	// no longer a comparable type and needs a deep comparison here.
+	if reflect.DeepEqual(m.config, cfg.TracingConfig) {
 		return nil
 	}
 
@@ -71,19 +81,49 @@ func (m *Manager) ApplyConfig(cfg *config.Config) error {
 		}
 	}
 
-	tp, shutdownFunc, err := buildTracerProvider(context.Background(), cfg.TracingConfig)
+	tp, shutdownFunc, err := buildTracerProvider(context.Background(), m.logger, cfg.TracingConfig)
 	if err != nil {
 		return errors.Wrap(err, "failed to install a new tracer provider")
 	}
 
 	m.shutdownFunc = shutdownFunc
 	m.config = cfg.TracingConfig
-	otel.SetTracerProvider(tp)
+	m.installTracerProvider(tp)
 
 	level.Info(m.logger).Log("msg", "Successfully installed a new tracer provider.")
 	return nil
 }
 
+// installTracerProvider installs tp as the global OTEL tracer provider. If
+// the OpenTracing bridge is enabled, it also builds a bridge tracer backed
+// by tp and installs it as the global OpenTracing tracer, so that
+// rebuilding the provider (e.g. on a config reload) also swaps the bridge
+// over to it.
+//
+// tp itself, not the bridge's WrapperTracerProvider, stays installed as the
+// OTEL global: WrapperTracerProvider.Tracer ignores the name it's given and
+// always returns the single tracer the bridge was built from, which would
+// collapse every subsystem's Tracer(name) call (see Tracer, below) onto one
+// instrumentation scope.
+func (m *Manager) installTracerProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+
+	if !m.enableOpenTracingBridge {
+		return
+	}
+
+	bridgeTracer, _ := otelbridge.NewTracerPair(tp.Tracer("go.opentelemetry.io/otel/bridge/opentracing"))
+	opentracing.SetGlobalTracer(bridgeTracer)
+}
+
+// Tracer returns a trace.Tracer scoped to name from the currently installed
+// global tracer provider. Subsystems should call this (or the package-level
+// Tracer helper) rather than reaching into otel.GetTracerProvider()
+// directly, so callers don't need to depend on this package's internals.
+func (m *Manager) Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
 // Shutdown gracefully shuts down the tracer provider.
 func (m *Manager) Shutdown() {
 	if m.shutdownFunc == nil {
@@ -95,6 +135,15 @@ func (m *Manager) Shutdown() {
 	}
 }
 
+// Tracer returns a trace.Tracer scoped to name from the currently installed
+// global tracer provider. It's a thin convenience wrapper over
+// otel.Tracer, for subsystems (the query engine, TSDB queriers, the
+// remote-write shard send path, scrape loops, ...) that want to create
+// spans without taking a dependency on a *Manager instance.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
 type otelErrHandler func(err error)
 
 func (o otelErrHandler) Handle(err error) {
@@ -103,13 +152,28 @@ func (o otelErrHandler) Handle(err error) {
 
 // buildTracerProvider return a new tracer provider ready for installation, together
 // with a shutdown function.
-func buildTracerProvider(ctx context.Context, tracingCfg config.TracingConfig) (trace.TracerProvider, func() error, error) {
-	exp, err := otlptrace.New(ctx, getClient(tracingCfg))
+func buildTracerProvider(ctx context.Context, logger log.Logger, tracingCfg config.TracingConfig) (trace.TracerProvider, func() error, error) {
+	// Neither prometheus.yml nor the standard OTEL environment variables
+	// point at an exporter endpoint: install a no-op provider rather than
+	// spinning up a client that will just retry against a default endpoint
+	// (e.g. localhost:4318) and flood the logs.
+	if !tracingEnabled(tracingCfg) {
+		return trace.NewNoopTracerProvider(), func() error { return nil }, nil
+	}
+
+	exp, err := buildExporter(ctx, tracingCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sampler, stopSampler, err := buildSampler(logger, tracingCfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Create a resource describing the service and the runtime.
+	// resource.WithFromEnv() applies OTEL_SERVICE_NAME and
+	// OTEL_RESOURCE_ATTRIBUTES on top of the attributes below.
 	res, err := resource.New(
 		ctx,
 		resource.WithSchemaURL(semconv.SchemaURL),
@@ -119,6 +183,7 @@ func buildTracerProvider(ctx context.Context, tracingCfg config.TracingConfig) (
 		),
 		resource.WithProcessRuntimeDescription(),
 		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
 	)
 	if err != nil {
 		return nil, nil, err
@@ -126,13 +191,13 @@ func buildTracerProvider(ctx context.Context, tracingCfg config.TracingConfig) (
 
 	tp := tracesdk.NewTracerProvider(
 		tracesdk.WithBatcher(exp),
-		tracesdk.WithSampler(tracesdk.ParentBased(
-			tracesdk.TraceIDRatioBased(tracingCfg.SamplingFraction),
-		)),
+		tracesdk.WithSampler(sampler),
 		tracesdk.WithResource(res),
 	)
 
 	return tp, func() error {
+		stopSampler()
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		err := tp.Shutdown(ctx)
@@ -143,26 +208,3 @@ func buildTracerProvider(ctx context.Context, tracingCfg config.TracingConfig) (
 		return nil
 	}, nil
 }
-
-// getClient returns an appropriate OTLP client (either gRPC or HTTP), based
-// on the provided tracing configuration.
-func getClient(tracingCfg config.TracingConfig) otlptrace.Client {
-	var client otlptrace.Client
-	switch tracingCfg.ClientType {
-	case config.TracingClientGRPC:
-		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(tracingCfg.Endpoint)}
-		if !tracingCfg.WithSecure {
-			opts = append(opts, otlptracegrpc.WithInsecure())
-		}
-
-		client = otlptracegrpc.NewClient(opts...)
-	case config.TracingClientHTTP:
-		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(tracingCfg.Endpoint)}
-		if !tracingCfg.WithSecure {
-			opts = append(opts, otlptracehttp.WithInsecure())
-		}
-		client = otlptracehttp.NewClient(opts...)
-	}
-
-	return client
-}